@@ -0,0 +1,44 @@
+// Command fanotify is a small demo that watches /tmp and reports every
+// filesystem event through a pluggable sink.EventSink.
+package main
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"github.com/desdic/fanotify/pkg/fanotify"
+	"github.com/desdic/fanotify/pkg/sink"
+)
+
+func main() {
+	watcher, err := fanotify.NewWatcher()
+	if err != nil {
+		log.Error().Err(err).Msg("init failed")
+
+		return
+	}
+	defer watcher.Close()
+
+	target := "/tmp"
+
+	if err := watcher.Add(target); err != nil {
+		log.Error().Err(err).Msg("mark failed")
+
+		return
+	}
+
+	out := sink.NewZerologSink(log.Logger)
+	defer out.Close()
+
+	for {
+		select {
+		case event := <-watcher.Events:
+			if err := out.Event(event); err != nil {
+				log.Error().Err(err).Msg("sink event failed")
+			}
+		case err := <-watcher.Errors:
+			if err := out.Error(err); err != nil {
+				log.Error().Err(err).Msg("sink error failed")
+			}
+		}
+	}
+}
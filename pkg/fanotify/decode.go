@@ -0,0 +1,218 @@
+package fanotify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+type fanotifyEventInfoHeader struct {
+	InfoType uint8
+	Pad      uint8
+	Len      uint16
+}
+
+// The following mirror only the body of their fanotify_event_info_*
+// struct, not the fanotify_event_info_header that precedes it: the loop in
+// decodeInfoRecords already reads and consumes that header before
+// dispatching on InfoType, so re-reading it here would eat 4 bytes that
+// belong to the body and misalign everything after it.
+
+type fanotifyEventInfoFid struct {
+	FSID uint64
+}
+
+type fanotifyEventInfoError struct {
+	Error      int32
+	ErrorCount uint32
+}
+
+type fanotifyEventInfoPidfd struct {
+	Pidfd int32
+}
+
+// decodedInfo accumulates the info records seen for a single event. A plain
+// notification carries a single resolved name; a FAN_RENAME event carries
+// oldName and newName instead (see FAN_EVENT_INFO_TYPE_OLD/NEW_DFID_NAME).
+type decodedInfo struct {
+	name    string
+	oldName string
+	newName string
+
+	pidfd    int32
+	hasPidfd bool
+}
+
+// decodeInfoRecords walks the variable list of fanotify_event_info_header
+// records that follow an event's metadata, from the reader's current
+// position up to end, dispatching on each record's InfoType. Any record
+// type it doesn't need is skipped using the record's own Len, so unknown or
+// future info types can't desync the stream.
+func (w *Watcher) decodeInfoRecords(rd *bytes.Reader, end int64) (decodedInfo, error) {
+	var info decodedInfo
+
+	for {
+		pos, err := rd.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return info, fmt.Errorf("locate reader: %w", err)
+		}
+
+		if pos >= end {
+			return info, nil
+		}
+
+		var header fanotifyEventInfoHeader
+
+		if err := binary.Read(rd, binary.LittleEndian, &header); err != nil {
+			return info, fmt.Errorf("read info header: %w", err)
+		}
+
+		const headerSize = 4 // InfoType + Pad + Len, fixed by the kernel ABI
+
+		if header.Len < headerSize {
+			// A record can't be shorter than its own header; trusting Len
+			// here would seek back to pos and read the same bytes forever.
+			// Treat the rest of this event as unparseable and stop.
+			if _, err := rd.Seek(end, io.SeekStart); err != nil {
+				return info, fmt.Errorf("skip malformed info record: %w", err)
+			}
+
+			return info, fmt.Errorf("info record at offset %d has invalid length %d", pos, header.Len)
+		}
+
+		recordEnd := pos + int64(header.Len)
+		if recordEnd > end {
+			recordEnd = end
+		}
+
+		switch header.InfoType {
+		case unix.FAN_EVENT_INFO_TYPE_FID, unix.FAN_EVENT_INFO_TYPE_DFID, unix.FAN_EVENT_INFO_TYPE_DFID_NAME:
+			if dir, name, err := w.resolveFidRecord(rd, recordEnd); err != nil {
+				w.reportResolveErr("decode fid record", err)
+			} else {
+				info.name = filepath.Join(dir, name)
+			}
+
+		case unix.FAN_EVENT_INFO_TYPE_OLD_DFID_NAME:
+			if dir, name, err := w.resolveFidRecord(rd, recordEnd); err != nil {
+				w.reportResolveErr("decode old dfid record", err)
+			} else {
+				info.oldName = filepath.Join(dir, name)
+			}
+
+		case unix.FAN_EVENT_INFO_TYPE_NEW_DFID_NAME:
+			if dir, name, err := w.resolveFidRecord(rd, recordEnd); err != nil {
+				w.reportResolveErr("decode new dfid record", err)
+			} else {
+				info.newName = filepath.Join(dir, name)
+			}
+
+		case unix.FAN_EVENT_INFO_TYPE_PIDFD:
+			var pidfdInfo fanotifyEventInfoPidfd
+
+			if err := binary.Read(rd, binary.LittleEndian, &pidfdInfo); err != nil {
+				w.emitError(fmt.Errorf("read pidfd info: %w", err))
+			} else {
+				info.pidfd = pidfdInfo.Pidfd
+				info.hasPidfd = true
+			}
+
+		case unix.FAN_EVENT_INFO_TYPE_ERROR:
+			var errInfo fanotifyEventInfoError
+
+			if err := binary.Read(rd, binary.LittleEndian, &errInfo); err != nil {
+				w.emitError(fmt.Errorf("read error info: %w", err))
+			} else {
+				w.emitError(fmt.Errorf("fanotify: dropped %d event(s): %w", errInfo.ErrorCount, unix.Errno(errInfo.Error)))
+			}
+
+		default:
+			// Unknown info type; skip it via recordEnd below.
+		}
+
+		if _, err := rd.Seek(recordEnd, io.SeekStart); err != nil {
+			return info, fmt.Errorf("advance past info record: %w", err)
+		}
+	}
+}
+
+// reportResolveErr emits err on Errors unless it's ESTALE, which is a common
+// and harmless race when a folder containing multiple files is removed all
+// at once: the more important underlying folder event doesn't produce it.
+func (w *Watcher) reportResolveErr(context string, err error) {
+	if errors.Is(err, unix.ESTALE) {
+		return
+	}
+
+	w.emitError(fmt.Errorf("%s: %w", context, err))
+}
+
+// resolveFidRecord decodes a fanotify_event_info_fid (FSID + file handle),
+// resolves the handle to a directory path via OpenByHandleAt, and reads any
+// trailing NUL-terminated name up to recordEnd.
+func (w *Watcher) resolveFidRecord(rd *bytes.Reader, recordEnd int64) (dir, name string, err error) {
+	var fid fanotifyEventInfoFid
+
+	if err := binary.Read(rd, binary.LittleEndian, &fid); err != nil {
+		return "", "", fmt.Errorf("read fid: %w", err)
+	}
+
+	// Although unix.FileHandle exists, it cannot be used with binary.Read() as the
+	// variables inside are not exported.
+	type fileHandleInfo struct {
+		Bytes uint32
+		Type  int32
+	}
+
+	var fhInfo fileHandleInfo
+
+	if err := binary.Read(rd, binary.LittleEndian, &fhInfo); err != nil {
+		return "", "", fmt.Errorf("read file handle info: %w", err)
+	}
+
+	fileHandle := make([]byte, fhInfo.Bytes)
+
+	if err := binary.Read(rd, binary.LittleEndian, &fileHandle); err != nil {
+		return "", "", fmt.Errorf("read file handle: %w", err)
+	}
+
+	fh := unix.NewFileHandle(fhInfo.Type, fileHandle)
+
+	mountFd := w.mountFdFor(fid.FSID)
+	if mountFd == -1 {
+		return "", "", fmt.Errorf("no mount fd registered for fsid %x", fid.FSID)
+	}
+
+	handleFd, err := unix.OpenByHandleAt(mountFd, fh, os.O_RDONLY)
+	if err != nil {
+		return "", "", fmt.Errorf("open file handle: %w", err)
+	}
+	defer unix.Close(handleFd)
+
+	dir, err = os.Readlink(fmt.Sprintf("/proc/self/fd/%d", handleFd))
+	if err != nil {
+		return "", "", fmt.Errorf("read symlink: %w", err)
+	}
+
+	// If the target file has been deleted, the returned value might contain a " (deleted)" suffix.
+	dir = strings.TrimSuffix(dir, " (deleted)")
+
+	if pos, _ := rd.Seek(0, io.SeekCurrent); pos < recordEnd {
+		nameBuf := make([]byte, recordEnd-pos)
+
+		if _, err := io.ReadFull(rd, nameBuf); err != nil {
+			return "", "", fmt.Errorf("read name: %w", err)
+		}
+
+		name = unix.ByteSliceToString(nameBuf)
+	}
+
+	return dir, name, nil
+}
@@ -0,0 +1,137 @@
+package fanotify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func newTestWatcher() *Watcher {
+	return &Watcher{
+		Errors: make(chan error, 10),
+		done:   make(chan struct{}),
+		marks:  make(map[string]mark),
+	}
+}
+
+func encodeRecord(t *testing.T, v any) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+		t.Fatalf("encode record: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecodeInfoRecordsUnknownTypeSkipped(t *testing.T) {
+	w := newTestWatcher()
+
+	record := fanotifyEventInfoHeader{InfoType: 0xFF, Len: 4}
+	buf := encodeRecord(t, record)
+
+	info, err := w.decodeInfoRecords(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatalf("decodeInfoRecords: %v", err)
+	}
+
+	if info != (decodedInfo{}) {
+		t.Errorf("decodeInfoRecords info = %+v, want zero value for an unknown record", info)
+	}
+}
+
+func TestDecodeInfoRecordsWalksMultipleRecords(t *testing.T) {
+	w := newTestWatcher()
+
+	var buf bytes.Buffer
+	buf.Write(encodeRecord(t, fanotifyEventInfoHeader{InfoType: 0xFF, Len: 4}))
+	buf.Write(encodeRecord(t, fanotifyEventInfoHeader{InfoType: 0xFE, Len: 4}))
+
+	if _, err := w.decodeInfoRecords(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+		t.Fatalf("decodeInfoRecords: %v", err)
+	}
+}
+
+func TestDecodeInfoRecordsRejectsShortLength(t *testing.T) {
+	w := newTestWatcher()
+
+	// Len shorter than the header itself: trusting it would seek back to
+	// the record's start and read the same header forever.
+	record := fanotifyEventInfoHeader{InfoType: 0xFF, Len: 1}
+	buf := encodeRecord(t, record)
+	buf = append(buf, make([]byte, 16)...) // pad so a runaway loop would have room to spin
+
+	if _, err := w.decodeInfoRecords(bytes.NewReader(buf), int64(len(buf))); err == nil {
+		t.Error("decodeInfoRecords with a too-short Len did not return an error")
+	}
+}
+
+// TestResolveFidRecordParsesFSIDAndHandle builds a real fanotify_event_info_fid
+// body (FSID + file handle + trailing name, no leading header, matching what
+// decodeInfoRecords hands resolveFidRecord after consuming the header itself)
+// and checks the FSID and file handle are read from the right offsets: a
+// 4-byte misalignment here previously turned a real FSID into garbage and
+// made every notification fail to resolve (see mountFdFor).
+func TestResolveFidRecordParsesFSIDAndHandle(t *testing.T) {
+	w := newTestWatcher()
+
+	dir := t.TempDir()
+
+	mountFd, err := unix.Open(dir, unix.O_DIRECTORY|unix.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("open %s: %v", dir, err)
+	}
+	defer unix.Close(mountFd)
+
+	var stat unix.Statfs_t
+	if err := unix.Fstatfs(mountFd, &stat); err != nil {
+		t.Fatalf("fstatfs: %v", err)
+	}
+
+	fsid := fsidToUint64(stat.Fsid)
+
+	w.marks["mark"] = mark{mountFd: mountFd, markType: MarkFilesystem}
+
+	type fileHandleInfo struct {
+		Bytes uint32
+		Type  int32
+	}
+
+	handle := []byte{0xde, 0xad, 0xbe, 0xef, 0x11, 0x22, 0x33, 0x44}
+
+	var buf bytes.Buffer
+	buf.Write(encodeRecord(t, fsid))
+	buf.Write(encodeRecord(t, fileHandleInfo{Bytes: uint32(len(handle)), Type: 1}))
+	buf.Write(handle)
+	buf.WriteString("target.txt")
+
+	rd := bytes.NewReader(buf.Bytes())
+	recordEnd := int64(buf.Len())
+
+	_, _, err = w.resolveFidRecord(rd, recordEnd)
+	if err == nil || !strings.Contains(err.Error(), "open file handle") {
+		t.Fatalf("resolveFidRecord err = %v, want an \"open file handle\" failure once FSID and handle parsed correctly", err)
+	}
+
+	wantPos := int64(8 + 8 + len(handle)) // FSID + fileHandleInfo + handle bytes, stopping before the trailing name
+	if gotPos, _ := rd.Seek(0, io.SeekCurrent); gotPos != wantPos {
+		t.Errorf("reader consumed %d bytes, want %d (FSID/handle misaligned)", gotPos, wantPos)
+	}
+}
+
+func TestDecodeInfoRecordsRejectsZeroLength(t *testing.T) {
+	w := newTestWatcher()
+
+	record := fanotifyEventInfoHeader{InfoType: 0xFF, Len: 0}
+	buf := encodeRecord(t, record)
+	buf = append(buf, make([]byte, 16)...)
+
+	if _, err := w.decodeInfoRecords(bytes.NewReader(buf), int64(len(buf))); err == nil {
+		t.Error("decodeInfoRecords with a zero Len did not return an error")
+	}
+}
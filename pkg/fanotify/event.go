@@ -0,0 +1,107 @@
+package fanotify
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Op describes a set of file operations, mirroring fsnotify's Op so that
+// code written against fsnotify.Watcher can be adapted to this package with
+// minimal changes.
+type Op uint32
+
+const (
+	Create Op = 1 << iota
+	Write
+	Remove
+	Rename
+	Chmod
+)
+
+func (op Op) String() string {
+	var names []string
+
+	if op&Create != 0 {
+		names = append(names, "CREATE")
+	}
+	if op&Write != 0 {
+		names = append(names, "WRITE")
+	}
+	if op&Remove != 0 {
+		names = append(names, "REMOVE")
+	}
+	if op&Rename != 0 {
+		names = append(names, "RENAME")
+	}
+	if op&Chmod != 0 {
+		names = append(names, "CHMOD")
+	}
+
+	return strings.Join(names, "|")
+}
+
+// Event represents a single filesystem notification decoded from the
+// fanotify event stream.
+type Event struct {
+	Name string // path the event applies to
+	Op   Op     // normalized operation bitmask
+
+	// OldName and NewName are populated, alongside Name (which then holds
+	// the new path), for a correlated Rename event, once the Watcher has
+	// paired the FAN_MOVED_FROM and FAN_MOVED_TO halves of a rename or
+	// decoded a FAN_RENAME event. An unpaired FAN_MOVED_TO also sets
+	// NewName; an unpaired FAN_MOVED_FROM (the pair timed out) is reported
+	// with only Name set.
+	OldName string
+	NewName string
+
+	// RawMask is the unmodified fanotify mask reported by the kernel, kept
+	// around for callers that need finer-grained information than Op
+	// exposes (e.g. FAN_ONDIR, FAN_ACCESS).
+	RawMask uint64
+
+	// Pid is the process that triggered the event. It comes straight from
+	// the event metadata and is always set.
+	Pid int32
+
+	// Exe, Comm and Cgroup enrich Pid with /proc/<pid>/exe,
+	// /proc/<pid>/comm and /proc/<pid>/cgroup. They require the Watcher to
+	// have been initialized with FAN_REPORT_PIDFD support; on kernels
+	// without it they are left empty and callers fall back to Pid alone.
+	Exe    string
+	Comm   string
+	Cgroup string
+}
+
+func (e Event) String() string {
+	if e.OldName != "" && e.NewName != "" {
+		return fmt.Sprintf("%q -> %q: %s", e.OldName, e.NewName, e.Op)
+	}
+
+	return fmt.Sprintf("%q: %s", e.Name, e.Op)
+}
+
+// opFromMask maps a raw fanotify mask onto the normalized Op bitmask.
+func opFromMask(mask uint64) Op {
+	var op Op
+
+	if mask&unix.FAN_CREATE != 0 {
+		op |= Create
+	}
+	if mask&unix.FAN_MODIFY != 0 || mask&unix.FAN_CLOSE_WRITE != 0 {
+		op |= Write
+	}
+	if mask&unix.FAN_DELETE != 0 || mask&unix.FAN_DELETE_SELF != 0 {
+		op |= Remove
+	}
+	if mask&unix.FAN_MOVED_FROM != 0 || mask&unix.FAN_MOVED_TO != 0 || mask&unix.FAN_MOVE_SELF != 0 || mask&unix.FAN_RENAME != 0 {
+		op |= Rename
+	}
+	if mask&unix.FAN_ATTRIB != 0 {
+		op |= Chmod
+	}
+
+	return op
+}
@@ -0,0 +1,53 @@
+package fanotify
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestOpFromMask(t *testing.T) {
+	tests := []struct {
+		name string
+		mask uint64
+		want Op
+	}{
+		{"create", unix.FAN_CREATE, Create},
+		{"modify", unix.FAN_MODIFY, Write},
+		{"close write", unix.FAN_CLOSE_WRITE, Write},
+		{"delete", unix.FAN_DELETE, Remove},
+		{"delete self", unix.FAN_DELETE_SELF, Remove},
+		{"moved from", unix.FAN_MOVED_FROM, Rename},
+		{"moved to", unix.FAN_MOVED_TO, Rename},
+		{"move self", unix.FAN_MOVE_SELF, Rename},
+		{"attrib", unix.FAN_ATTRIB, Chmod},
+		{"create and modify", unix.FAN_CREATE | unix.FAN_MODIFY, Create | Write},
+		{"unmapped bits only", unix.FAN_OPEN, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := opFromMask(tt.mask); got != tt.want {
+				t.Errorf("opFromMask(%#x) = %v, want %v", tt.mask, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpString(t *testing.T) {
+	tests := []struct {
+		op   Op
+		want string
+	}{
+		{Create, "CREATE"},
+		{Create | Write, "CREATE|WRITE"},
+		{Rename | Chmod, "RENAME|CHMOD"},
+		{0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.op.String(); got != tt.want {
+			t.Errorf("Op(%d).String() = %q, want %q", tt.op, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package fanotify
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestFsidToUint64(t *testing.T) {
+	tests := []struct {
+		name string
+		fsid unix.Fsid
+		want uint64
+	}{
+		{"zero", unix.Fsid{Val: [2]int32{0, 0}}, 0},
+		{"low only", unix.Fsid{Val: [2]int32{0x1234, 0}}, 0x1234},
+		{"high only", unix.Fsid{Val: [2]int32{0, 0x5678}}, 0x5678 << 32},
+		{"both halves", unix.Fsid{Val: [2]int32{0x1, 0x2}}, 0x0000000200000001},
+		{"negative halves treated as unsigned", unix.Fsid{Val: [2]int32{-1, -1}}, 0xFFFFFFFFFFFFFFFF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fsidToUint64(tt.fsid); got != tt.want {
+				t.Errorf("fsidToUint64(%+v) = %#x, want %#x", tt.fsid, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package fanotify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// defaultPermissionTimeout is used when a Watcher has a PermissionHandler
+// but no explicit PermissionTimeout, so a caller who forgets to set one
+// cannot hang every process touching the watched path.
+const defaultPermissionTimeout = 5 * time.Second
+
+// Decision is the verdict a PermissionHandler returns for a permission
+// event; it is written back to the kernel as-is via fanotify_response.
+type Decision uint32
+
+const (
+	Allow Decision = unix.FAN_ALLOW
+	Deny  Decision = unix.FAN_DENY
+)
+
+// Option configures a Watcher at construction time.
+type Option func(*watcherConfig)
+
+type watcherConfig struct {
+	classFlags int
+	permMask   uint64
+}
+
+// WithPermissionEvents switches the Watcher into fanotify's permission mode,
+// where mask events (typically FAN_OPEN_PERM and/or FAN_ACCESS_PERM) block
+// the originating syscall until the Watcher's PermissionHandler replies with
+// an Allow or Deny decision. preContent selects FAN_CLASS_PRE_CONTENT
+// (handler sees the file before its content is available, e.g. on-access
+// scanners) over FAN_CLASS_CONTENT (handler sees it after).
+func WithPermissionEvents(preContent bool, mask uint64) Option {
+	return func(c *watcherConfig) {
+		if preContent {
+			c.classFlags = unix.FAN_CLASS_PRE_CONTENT
+		} else {
+			c.classFlags = unix.FAN_CLASS_CONTENT
+		}
+
+		c.permMask = mask
+	}
+}
+
+func (w *Watcher) handlePermissionEvent(meta unix.FanotifyEventMetadata) {
+	defer unix.Close(int(meta.Fd))
+
+	decision := Allow
+
+	if w.PermissionHandler != nil {
+		event := Event{
+			Op:      opFromMask(meta.Mask),
+			RawMask: meta.Mask,
+			Pid:     meta.Pid,
+		}
+
+		if name, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", meta.Fd)); err == nil {
+			event.Name = name
+		}
+
+		decision = w.runPermissionHandler(event)
+	}
+
+	if err := w.respond(meta.Fd, decision); err != nil {
+		w.emitError(fmt.Errorf("permission response: %w", err))
+	}
+}
+
+// runPermissionHandler calls PermissionHandler on its own goroutine and
+// defaults to Allow if it doesn't reply within PermissionTimeout.
+func (w *Watcher) runPermissionHandler(event Event) Decision {
+	timeout := w.PermissionTimeout
+	if timeout <= 0 {
+		timeout = defaultPermissionTimeout
+	}
+
+	result := make(chan Decision, 1)
+
+	go func() { result <- w.PermissionHandler(event) }()
+
+	select {
+	case decision := <-result:
+		return decision
+	case <-time.After(timeout):
+		w.emitError(fmt.Errorf("permission handler for %q timed out after %s, defaulting to allow", event.Name, timeout))
+
+		return Allow
+	}
+}
+
+func (w *Watcher) respond(fd int32, decision Decision) error {
+	resp := unix.FanotifyResponse{Fd: fd, Response: uint32(decision)}
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, resp); err != nil {
+		return fmt.Errorf("encode response: %w", err)
+	}
+
+	if _, err := unix.Write(w.fd, buf.Bytes()); err != nil {
+		return fmt.Errorf("write response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package fanotify
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// enrichFromPidfd resolves the process behind pidfd (delivered via
+// FAN_EVENT_INFO_TYPE_PIDFD) into the pid, executable path, comm and cgroup
+// that Event exposes, and closes pidfd once it's done with it. It returns
+// ok=false if the pidfd couldn't be resolved, in which case the caller
+// should fall back to the plain pid from the event metadata.
+func enrichFromPidfd(pidfd int32) (pid int32, exe, comm, cgroup string, ok bool) {
+	defer unix.Close(int(pidfd))
+
+	fdinfo, err := os.ReadFile(fmt.Sprintf("/proc/self/fdinfo/%d", pidfd))
+	if err != nil {
+		return 0, "", "", "", false
+	}
+
+	for _, line := range strings.Split(string(fdinfo), "\n") {
+		after, found := strings.CutPrefix(line, "Pid:")
+		if !found {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(strings.TrimSpace(after))
+		if err != nil {
+			return 0, "", "", "", false
+		}
+
+		pid = int32(parsed)
+
+		break
+	}
+
+	// fdinfo reports Pid: -1 once the process behind the pidfd has exited.
+	if pid <= 0 {
+		return 0, "", "", "", false
+	}
+
+	exe, _ = os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid)); err == nil {
+		comm = strings.TrimSpace(string(data))
+	}
+
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid)); err == nil {
+		cgroup = strings.TrimSpace(string(data))
+	}
+
+	return pid, exe, comm, cgroup, true
+}
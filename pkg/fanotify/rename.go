@@ -0,0 +1,92 @@
+package fanotify
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// renameCorrelationTimeout bounds how long a lone FAN_MOVED_FROM is held
+// waiting for its FAN_MOVED_TO before being reported as a half-event.
+const renameCorrelationTimeout = 500 * time.Millisecond
+
+type pendingRename struct {
+	name    string
+	expires time.Time
+}
+
+// renameCorrelator pairs the FAN_MOVED_FROM/FAN_MOVED_TO halves of a rename
+// into a single Event carrying both OldName and NewName.
+//
+// fanotify has no equivalent of inotify's rename cookie, so pairs are
+// correlated by the pid that issued the rename(2) syscall: the kernel emits
+// MOVED_FROM immediately before MOVED_TO for a given call, so "the most
+// recent unpaired half-rename from this pid" is a reliable match in
+// practice. Events that carry FAN_RENAME's OLD_DFID_NAME/NEW_DFID_NAME info
+// records are already paired by the kernel and do not pass through here.
+// renameCorrelator's pending map is read and written from both the
+// readEvents goroutine (via observe) and the Watcher's rename-expiry
+// goroutine (via expire), so access is guarded by mu.
+type renameCorrelator struct {
+	mu      sync.Mutex
+	pending map[int32]pendingRename
+}
+
+func newRenameCorrelator() *renameCorrelator {
+	return &renameCorrelator{pending: make(map[int32]pendingRename)}
+}
+
+// observe folds a rename-flavoured event into the correlator. ok reports
+// whether event should be emitted now; when it is false the event has been
+// buffered awaiting its other half.
+func (r *renameCorrelator) observe(pid int32, event Event) (out Event, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case event.RawMask&unix.FAN_MOVED_FROM != 0:
+		r.pending[pid] = pendingRename{name: event.Name, expires: time.Now().Add(renameCorrelationTimeout)}
+
+		return Event{}, false
+
+	case event.RawMask&unix.FAN_MOVED_TO != 0:
+		if old, ok := r.pending[pid]; ok {
+			delete(r.pending, pid)
+			event.OldName = old.name
+			event.NewName = event.Name
+
+			return event, true
+		}
+
+		event.NewName = event.Name
+
+		return event, true
+
+	default:
+		return event, true
+	}
+}
+
+// expire reports any FAN_MOVED_FROM halves that timed out unpaired via
+// emit, so they aren't dropped on the floor.
+func (r *renameCorrelator) expire(emit func(Event)) {
+	r.mu.Lock()
+
+	now := time.Now()
+
+	var timedOut []pendingRename
+
+	for pid, p := range r.pending {
+		if now.After(p.expires) {
+			delete(r.pending, pid)
+			timedOut = append(timedOut, p)
+		}
+	}
+
+	r.mu.Unlock()
+
+	for _, p := range timedOut {
+		emit(Event{Name: p.name, Op: Rename, RawMask: unix.FAN_MOVED_FROM})
+	}
+}
@@ -0,0 +1,94 @@
+package fanotify
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestRenameCorrelatorObserve(t *testing.T) {
+	r := newRenameCorrelator()
+
+	const pid = 42
+
+	from := Event{Name: "old.txt", RawMask: unix.FAN_MOVED_FROM}
+	if _, ok := r.observe(pid, from); ok {
+		t.Fatalf("observe(FAN_MOVED_FROM) reported ok, want buffered")
+	}
+
+	to := Event{Name: "new.txt", RawMask: unix.FAN_MOVED_TO}
+
+	out, ok := r.observe(pid, to)
+	if !ok {
+		t.Fatalf("observe(FAN_MOVED_TO) reported not ok, want emitted")
+	}
+
+	if out.OldName != "old.txt" || out.NewName != "new.txt" {
+		t.Errorf("observe paired OldName=%q NewName=%q, want old.txt/new.txt", out.OldName, out.NewName)
+	}
+
+	if _, pending := r.pending[pid]; pending {
+		t.Errorf("pid %d still pending after pairing", pid)
+	}
+}
+
+func TestRenameCorrelatorObserveUnpairedTo(t *testing.T) {
+	r := newRenameCorrelator()
+
+	to := Event{Name: "new.txt", RawMask: unix.FAN_MOVED_TO}
+
+	out, ok := r.observe(99, to)
+	if !ok {
+		t.Fatalf("observe(FAN_MOVED_TO) reported not ok, want emitted")
+	}
+
+	if out.OldName != "" || out.NewName != "new.txt" {
+		t.Errorf("unpaired MOVED_TO OldName=%q NewName=%q, want empty/new.txt", out.OldName, out.NewName)
+	}
+}
+
+func TestRenameCorrelatorObservePassthrough(t *testing.T) {
+	r := newRenameCorrelator()
+
+	event := Event{Name: "plain.txt", RawMask: unix.FAN_MODIFY}
+
+	out, ok := r.observe(1, event)
+	if !ok || out.Name != "plain.txt" {
+		t.Errorf("observe(non-rename) = %+v, %v, want passthrough", out, ok)
+	}
+}
+
+func TestRenameCorrelatorExpire(t *testing.T) {
+	r := newRenameCorrelator()
+
+	const pid = 7
+
+	r.pending[pid] = pendingRename{name: "stuck.txt", expires: time.Now().Add(-time.Millisecond)}
+
+	var emitted []Event
+
+	r.expire(func(e Event) { emitted = append(emitted, e) })
+
+	if len(emitted) != 1 || emitted[0].Name != "stuck.txt" || emitted[0].Op != Rename {
+		t.Errorf("expire emitted %+v, want one half-rename for stuck.txt", emitted)
+	}
+
+	if _, pending := r.pending[pid]; pending {
+		t.Errorf("pid %d still pending after expire", pid)
+	}
+}
+
+func TestRenameCorrelatorExpireNotYetDue(t *testing.T) {
+	r := newRenameCorrelator()
+
+	r.pending[1] = pendingRename{name: "fresh.txt", expires: time.Now().Add(time.Hour)}
+
+	var emitted []Event
+
+	r.expire(func(e Event) { emitted = append(emitted, e) })
+
+	if len(emitted) != 0 {
+		t.Errorf("expire emitted %+v before the timeout elapsed", emitted)
+	}
+}
@@ -0,0 +1,274 @@
+// Package fanotify provides a Watcher on top of the Linux fanotify(7) API,
+// shaped after fsnotify.Watcher so that filesystem-monitoring code can be
+// written in a familiar style.
+package fanotify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const markMask = unix.FAN_DELETE | unix.FAN_MODIFY | unix.FAN_CLOSE_WRITE | unix.FAN_CREATE | unix.FAN_MOVE
+
+// ErrOverflow is reported on Errors when the kernel's fanotify event queue
+// overflowed (FAN_Q_OVERFLOW). Events were dropped while this was happening;
+// callers that need an accurate view of the filesystem should treat it as a
+// signal to rescan their watched paths, or set OnOverflow to do so
+// automatically.
+var ErrOverflow = errors.New("fanotify: event queue overflowed, events may have been lost")
+
+// Watcher watches a set of paths for filesystem activity using fanotify and
+// reports decoded Events (and any decode/read errors) on its channels.
+//
+// Events and Errors must be drained by the caller; readEvents pushes to them
+// non-blockingly against done so Close never deadlocks on a slow consumer.
+type Watcher struct {
+	Events chan Event
+	Errors chan error
+
+	// PermissionHandler, when set, is invoked for every FAN_OPEN_PERM /
+	// FAN_ACCESS_PERM event so the caller can allow or deny the syscall
+	// that triggered it. It is only consulted when the Watcher was
+	// created with WithPermissionEvents.
+	PermissionHandler func(Event) Decision
+
+	// PermissionTimeout bounds how long PermissionHandler may take to
+	// decide. If it does not return in time, the event defaults to Allow
+	// so a slow or stuck handler cannot hang the originating process.
+	PermissionTimeout time.Duration
+
+	// OnOverflow, when set, is called after ErrOverflow is reported so a
+	// caller can resync its view of the filesystem, e.g. by rescanning
+	// its watched roots.
+	OnOverflow func()
+
+	fd          int
+	permMask    uint64
+	contentMode bool
+	renames     *renameCorrelator
+	done        chan struct{}
+
+	marksMu sync.RWMutex
+	marks   map[string]mark
+}
+
+// NewWatcher initializes a fanotify fd and starts the background event loop.
+// Add must be called at least once before any events are reported.
+func NewWatcher(opts ...Option) (*Watcher, error) {
+	cfg := watcherConfig{classFlags: unix.FAN_CLASS_NOTIF}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	initFlags := cfg.classFlags
+
+	openFlags := unix.O_RDONLY
+	if cfg.classFlags != unix.FAN_CLASS_NOTIF {
+		// Permission events require the fanotify fd to be writable so
+		// decisions can be written back as fanotify_response structs, and
+		// they cannot be combined with the FID-based reporting used for
+		// plain notification events.
+		openFlags = unix.O_RDWR
+	} else {
+		initFlags |= unix.FAN_REPORT_DFID_NAME
+	}
+
+	fd, err := unix.FanotifyInit(uint(initFlags|unix.FAN_REPORT_PIDFD), uint(openFlags)) //nolint:nosnakecase,varnamelen
+	if errors.Is(err, unix.EINVAL) {
+		// FAN_REPORT_PIDFD needs a 5.15+ kernel; fall back to plain pids.
+		fd, err = unix.FanotifyInit(uint(initFlags), uint(openFlags))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fanotify init: %w", err)
+	}
+
+	w := &Watcher{
+		Events:            make(chan Event),
+		Errors:            make(chan error),
+		PermissionTimeout: defaultPermissionTimeout,
+		fd:                fd,
+		permMask:          cfg.permMask,
+		contentMode:       cfg.classFlags != unix.FAN_CLASS_NOTIF,
+		renames:           newRenameCorrelator(),
+		done:              make(chan struct{}),
+		marks:             make(map[string]mark),
+	}
+
+	go w.readEvents()
+	go w.expireRenames()
+
+	return w, nil
+}
+
+// Close stops the event loop, removes every mark and releases the
+// underlying fanotify fd along with each watched path's mount fd.
+func (w *Watcher) Close() error {
+	close(w.done)
+
+	w.marksMu.Lock()
+	for _, m := range w.marks {
+		_ = unix.Close(m.mountFd)
+	}
+	w.marks = nil
+	w.marksMu.Unlock()
+
+	return unix.Close(w.fd)
+}
+
+// expireRenames periodically flushes timed-out half-renames so a lone
+// FAN_MOVED_FROM on an otherwise quiet filesystem is still reported instead
+// of waiting for the next event to pass through processEvent.
+func (w *Watcher) expireRenames() {
+	ticker := time.NewTicker(renameCorrelationTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.renames.expire(w.emitEvent)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *Watcher) readEvents() { //nolint:cyclop
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := unix.Read(w.fd, buf)
+		if err != nil {
+			w.emitError(fmt.Errorf("read: %w", err))
+
+			return
+		}
+
+		rd := bytes.NewReader(buf)
+		var offset int64
+
+		for offset < int64(n) {
+			var event unix.FanotifyEventMetadata
+
+			if err := binary.Read(rd, binary.LittleEndian, &event); err != nil {
+				w.emitError(fmt.Errorf("read event metadata: %w", err))
+
+				break
+			}
+
+			end := offset + int64(event.Event_len)
+
+			if event.Mask&unix.FAN_Q_OVERFLOW != 0 {
+				w.emitError(ErrOverflow)
+
+				if w.OnOverflow != nil {
+					w.OnOverflow()
+				}
+
+				offset, _ = rd.Seek(end, io.SeekStart)
+
+				continue
+			}
+
+			if event.Mask&(unix.FAN_OPEN_PERM|unix.FAN_ACCESS_PERM) != 0 {
+				w.handlePermissionEvent(event)
+				offset, _ = rd.Seek(end, io.SeekStart)
+
+				continue
+			}
+
+			w.processEvent(rd, event, end)
+
+			offset, err = rd.Seek(end, io.SeekStart)
+			if err != nil {
+				w.emitError(fmt.Errorf("set new offset: %w", err))
+
+				break
+			}
+		}
+	}
+}
+
+// processEvent decodes and dispatches a single non-permission event. In
+// content/pre-content mode every event (not just permission events) carries
+// an open fd on event.Fd that the kernel expects the reader to close;
+// notification-mode events use FAN_REPORT_DFID_NAME instead and never set
+// one.
+func (w *Watcher) processEvent(rd *bytes.Reader, event unix.FanotifyEventMetadata, end int64) {
+	if w.contentMode {
+		defer unix.Close(int(event.Fd))
+	}
+
+	decoded, err := w.decodeInfoRecords(rd, end)
+	if err != nil {
+		w.emitError(fmt.Errorf("decode info records: %w", err))
+
+		return
+	}
+
+	w.renames.expire(w.emitEvent)
+	w.dispatch(event, decoded)
+}
+
+// dispatch turns the decoded info records for one event into the Event(s)
+// reported on Events, correlating lone FAN_MOVED_FROM/FAN_MOVED_TO halves
+// when the kernel didn't already pair them via FAN_RENAME info records.
+func (w *Watcher) dispatch(event unix.FanotifyEventMetadata, decoded decodedInfo) {
+	op := opFromMask(event.Mask)
+
+	base := Event{Op: op, RawMask: event.Mask, Pid: event.Pid}
+
+	if decoded.hasPidfd {
+		if pid, exe, comm, cgroup, ok := enrichFromPidfd(decoded.pidfd); ok {
+			base.Pid, base.Exe, base.Comm, base.Cgroup = pid, exe, comm, cgroup
+		}
+	}
+
+	switch {
+	case decoded.oldName != "" || decoded.newName != "":
+		base.Name = decoded.newName
+		if base.Name == "" {
+			base.Name = decoded.oldName
+		}
+
+		base.OldName, base.NewName = decoded.oldName, decoded.newName
+
+		w.emitEvent(base)
+
+	case decoded.name == "":
+		// Nothing resolved for this event (any decode error other than
+		// ESTALE was already emitted on Errors), so there's nothing
+		// meaningful to report.
+
+	case op&Rename != 0:
+		base.Name = decoded.name
+		if out, ok := w.renames.observe(event.Pid, base); ok {
+			w.emitEvent(out)
+		}
+
+	default:
+		base.Name = decoded.name
+		w.emitEvent(base)
+	}
+}
+
+func (w *Watcher) emitEvent(event Event) {
+	select {
+	case w.Events <- event:
+	case <-w.done:
+	}
+}
+
+func (w *Watcher) emitError(err error) {
+	select {
+	case w.Errors <- err:
+	case <-w.done:
+	}
+}
@@ -0,0 +1,70 @@
+package sink
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/desdic/fanotify/pkg/fanotify"
+)
+
+type ndjsonEvent struct {
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	Name    string    `json:"name,omitempty"`
+	OldName string    `json:"old_name,omitempty"`
+	NewName string    `json:"new_name,omitempty"`
+	Pid     int32     `json:"pid"`
+	Exe     string    `json:"exe,omitempty"`
+	Comm    string    `json:"comm,omitempty"`
+	Cgroup  string    `json:"cgroup,omitempty"`
+}
+
+type ndjsonError struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+}
+
+func newNDJSONEvent(event fanotify.Event) ndjsonEvent {
+	return ndjsonEvent{
+		Time:    time.Now().UTC(),
+		Op:      event.Op.String(),
+		Name:    event.Name,
+		OldName: event.OldName,
+		NewName: event.NewName,
+		Pid:     event.Pid,
+		Exe:     event.Exe,
+		Comm:    event.Comm,
+		Cgroup:  event.Cgroup,
+	}
+}
+
+// NDJSONSink writes one JSON object per line for every event and error, to
+// any io.Writer (e.g. os.Stdout or an open *os.File).
+type NDJSONSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewNDJSONSink(w io.Writer) *NDJSONSink {
+	return &NDJSONSink{enc: json.NewEncoder(w)}
+}
+
+func (s *NDJSONSink) Event(event fanotify.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.enc.Encode(newNDJSONEvent(event))
+}
+
+func (s *NDJSONSink) Error(err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.enc.Encode(ndjsonError{Time: time.Now().UTC(), Error: err.Error()})
+}
+
+func (s *NDJSONSink) Close() error {
+	return nil
+}
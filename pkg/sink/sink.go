@@ -0,0 +1,20 @@
+// Package sink provides pluggable destinations for fanotify.Watcher events,
+// so downstream tools can consume a watched filesystem's activity without
+// each writing their own event loop.
+package sink
+
+import (
+	"io"
+
+	"github.com/desdic/fanotify/pkg/fanotify"
+)
+
+// EventSink forwards events and errors observed by a Watcher to some
+// output. Event and Error are called from the same goroutine that drains
+// the Watcher's channels, so implementations don't need to be safe for
+// concurrent calls to Event/Error themselves, only for Close racing them.
+type EventSink interface {
+	Event(fanotify.Event) error
+	Error(error) error
+	io.Closer
+}
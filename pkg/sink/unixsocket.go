@@ -0,0 +1,100 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/desdic/fanotify/pkg/fanotify"
+)
+
+// writeTimeout bounds how long broadcast waits on a single client's socket
+// buffer before giving up on it, so one slow or stuck reader can't stall the
+// watcher's event loop behind a blocking Write.
+const writeTimeout = time.Second
+
+// UnixSocketSink listens on a Unix domain socket and streams every event
+// and error as an NDJSON line to each connected client, so tools like
+// auditd replacements or container runtimes can attach to the stream
+// on demand instead of embedding the watcher themselves.
+type UnixSocketSink struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	clients map[net.Conn]struct{}
+}
+
+func NewUnixSocketSink(path string) (*UnixSocketSink, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("remove stale socket %s: %w", path, err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", path, err)
+	}
+
+	s := &UnixSocketSink{listener: listener, clients: make(map[net.Conn]struct{})}
+
+	go s.acceptLoop()
+
+	return s, nil
+}
+
+func (s *UnixSocketSink) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.clients[conn] = struct{}{}
+		s.mu.Unlock()
+	}
+}
+
+func (s *UnixSocketSink) broadcast(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.clients {
+		_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+
+		if _, err := conn.Write(line); err != nil {
+			_ = conn.Close()
+			delete(s.clients, conn)
+		}
+	}
+
+	return nil
+}
+
+func (s *UnixSocketSink) Event(event fanotify.Event) error {
+	return s.broadcast(newNDJSONEvent(event))
+}
+
+func (s *UnixSocketSink) Error(err error) error {
+	return s.broadcast(ndjsonError{Time: time.Now().UTC(), Error: err.Error()})
+}
+
+func (s *UnixSocketSink) Close() error {
+	s.mu.Lock()
+	for conn := range s.clients {
+		_ = conn.Close()
+	}
+	s.clients = nil
+	s.mu.Unlock()
+
+	return s.listener.Close()
+}
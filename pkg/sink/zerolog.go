@@ -0,0 +1,33 @@
+package sink
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/desdic/fanotify/pkg/fanotify"
+)
+
+// ZerologSink logs events and errors through a zerolog.Logger, matching the
+// fanotify command's original behaviour before EventSink existed.
+type ZerologSink struct {
+	logger zerolog.Logger
+}
+
+func NewZerologSink(logger zerolog.Logger) *ZerologSink {
+	return &ZerologSink{logger: logger}
+}
+
+func (s *ZerologSink) Event(event fanotify.Event) error {
+	s.logger.Info().Msgf("%s", event)
+
+	return nil
+}
+
+func (s *ZerologSink) Error(err error) error {
+	s.logger.Error().Err(err).Msg("watcher error")
+
+	return nil
+}
+
+func (s *ZerologSink) Close() error {
+	return nil
+}